@@ -4,11 +4,15 @@ import (
 	"errors"
 	ht "html/template"
 	"io"
-	"io/ioutil"
+	"io/fs"
 	"os"
+	vpath "path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	tt "text/template"
+	"text/template/parse"
 )
 
 var (
@@ -16,12 +20,43 @@ var (
 	TemplateNotFoundErr = errors.New("The template could not be found.")
 )
 
+//baseTemplateFileName is the convention-based file name that, when found
+//in a template directory, is treated as the base layout for every
+//template that lives in that directory (and, transitively, its
+//sub-directories that don't define their own baseof.html).
+const baseTemplateFileName = "baseof.html"
+
 type Manager interface {
 	//AddDirectories will add a base directory to be scanned for templates
 	//Any future directories you add SHOULD NOT be a descendant of a directory
 	//that was previously added. Call ParseTemplates to parse templates in the
 	//directories
+	//
+	//Internally this is just AddFS(dir, os.DirFS(dir)) with dir resolved
+	//to an absolute path first.
 	AddDirectories(dirs ...string) (Manager, error)
+	//AddFS adds an io/fs.FS to be scanned for templates, rooted at
+	//prefix. Use this to ship templates inside an embed.FS for
+	//single-binary deployments, to plug in an overlay filesystem (e.g.
+	//user overrides layered on top of defaults), or to drive tests
+	//against an in-memory fstest.MapFS instead of real files. prefix is
+	//used the same way a directory passed to AddDirectories is: it's the
+	//virtual root aliases and baseof.html lookups are computed relative
+	//to, so it must be unique across every AddDirectories/AddFS call.
+	AddFS(prefix string, fsys fs.FS) Manager
+	//AddLayer adds dir as a named, ordered layer to be scanned for
+	//templates, the same way AddDirectories does, except overlapping
+	//trees are allowed: if a later layer defines a template under the
+	//same short alias as an earlier one (Hugo's theme/site composition),
+	//the later layer wins. The earlier, shadowed template is still
+	//reachable under the qualified name "name:alias", so an override can
+	//delegate back to the original it's customizing.
+	//
+	//AddDirectories and AddFS add their own layer too, named after their
+	//directory/prefix; priority across every layer, regardless of which
+	//of these three methods added it, follows call order - whichever was
+	//added last wins.
+	AddLayer(name, dir string) (Manager, error)
 	//AddFileExtension adds a file extension that will be considered
 	//a template. By default, both .html and .tpl will be considered
 	//templates.
@@ -37,13 +72,50 @@ type Manager interface {
 	Delims(left, right string) Manager
 	//Funcs sets the FuncMap for all the templates
 	Funcs(funcMap ht.FuncMap) Manager
-	//Lookup finds a template by name
+	//Lookup finds an html/template template by name. Use LookupText
+	//for a template registered under one of the text extensions.
 	Lookup(name string) *ht.Template
+	//LookupText finds a text/template template by name. This only finds
+	//templates whose extension was marked with SetTextExtensions.
+	LookupText(name string) *tt.Template
+	//SetTextExtensions marks the given extensions (already registered via
+	//AddFileExtension or the html/tpl defaults) to be parsed with
+	//text/template instead of html/template, so their output isn't
+	//HTML-escaped. Use this for RSS, sitemaps, plain-text email, or JSON
+	//templates living alongside your HTML pages.
+	SetTextExtensions(exts ...string) Manager
+	//SetHTMLExtensions marks the given extensions to be parsed with
+	//html/template. Every registered extension is html/template by
+	//default; use this to move one back out of text mode.
+	SetHTMLExtensions(exts ...string) Manager
+	//SetAliasStrategy overrides the default Pattern Lab-style alias
+	//generation (see templateAliases) with fn, for callers who need a
+	//different naming scheme - e.g. preserving numeric prefixes, using
+	//dots instead of hyphens, or a flat basename-only scheme. fn is
+	//called with the same root and virtual path buildSnapshot would
+	//otherwise pass to templateAliases, and must return every alias the
+	//template should be reachable under; the first alias becomes the
+	//name the template is created under. Call this before ParseTemplates.
+	SetAliasStrategy(fn func(root, path string) []string) Manager
 	//ParseTemplates parses all templates found in the directories
 	//added by AddDirectories calls and any directories passed in here
 	//Any errors encountered during reading the files are returned
 	//in the slice of errors
 	//
+	//Any directory containing a file named baseof.html is treated as
+	//a base layout for every template in that directory. Templates in
+	//sub-directories without their own baseof.html inherit the closest
+	//ancestor's one. ExecuteTemplate will wrap the requested template in
+	//its base, injecting blocks the template defines (e.g. {{define "main"}})
+	//into the base before executing it.
+	//
+	//ParseTemplates builds the new template tree off to the side and
+	//atomically swaps it in once it's ready, so Lookup, ExecuteTemplate,
+	//and Templates never see a half-built tree. Concurrent calls to
+	//ParseTemplates (including ones triggered by SetReparseOnExecute(true))
+	//join whichever build is already in progress instead of each
+	//re-walking the filesystem.
+	//
 	//If you wish to update the template definitions, because
 	//you are writing new templates during http requests,
 	//call ParseTemplates again with no arguments. It will reparse
@@ -67,112 +139,398 @@ type Manager interface {
 
 	//Templates returns the number of templates in the manager
 	Templates() []*ht.Template
+	//TextTemplates returns the text/template templates in the manager,
+	//i.e. the ones parsed from a text extension (see SetTextExtensions).
+	TextTemplates() []*tt.Template
+}
+
+//snapshot is the immutable result of a single ParseTemplates build. Once
+//published via manager.snap, nothing mutates it again, so reads never
+//need to take a lock.
+type snapshot struct {
+	root          *ht.Template
+	textRoot      *tt.Template
+	templates     []*ht.Template
+	textTemplates []*tt.Template
+
+	//baseTemplates maps the virtual path of each directory that holds a
+	//baseof.html to the *ht.Template parsed out of that file.
+	baseTemplates map[string]*ht.Template
+	//templateDirs maps each of a template's aliases to the directory it was
+	//parsed from so ExecuteTemplate can walk up looking for a base.
+	templateDirs map[string]string
+	//childBlocks maps each of a template's aliases to the named blocks
+	//({{define "main"}}, {{define "title"}}, etc.) it declared. These are
+	//captured from a standalone parse of the file so that sibling pages
+	//defining blocks of the same name don't clobber each other.
+	childBlocks map[string]map[string]*parse.Tree
+	//isText records, per alias, whether it was parsed into textRoot
+	//rather than root, so ExecuteTemplate/Lookup know which to use.
+	isText map[string]bool
+}
+
+func emptySnapshot() *snapshot {
+	return &snapshot{
+		root:          ht.New("atomic-template-manager"),
+		textRoot:      tt.New("atomic-template-manager-text"),
+		templates:     make([]*ht.Template, 0),
+		textTemplates: make([]*tt.Template, 0),
+		baseTemplates: make(map[string]*ht.Template),
+		templateDirs:  make(map[string]string),
+		childBlocks:   make(map[string]map[string]*parse.Tree),
+		isText:        make(map[string]bool),
+	}
 }
 
 type manager struct {
-	rootex     *sync.Mutex
-	root       *ht.Template
-	funcMap    ht.FuncMap
-	dirs       map[string]bool
-	extensions map[string]bool
-	templates  []*ht.Template
-	reparse    bool
+	//cfgMu guards the configuration fields below it, up to snap. They're
+	//only touched by the configuration methods (AddDirectories, Funcs,
+	//Delims, ...), by ExecuteTemplate when it reads reparse, and by
+	//ParseTemplates when it copies them at the start of a build, so
+	//contention is negligible compared to the template-execution path
+	//this replaces.
+	cfgMu          sync.Mutex
+	layers         []templateLayer
+	extensions     map[string]bool
+	textExtensions map[string]bool
+	funcMap        ht.FuncMap
+	reparse        bool
+	aliasStrategy  func(root, path string) []string
 
 	leftDelim, rightDelim string
+
+	//snap holds the current *snapshot. ParseTemplates is the only thing
+	//that ever stores into it, and it always stores a fully-built
+	//snapshot, so Lookup/ExecuteTemplate/Templates/TextTemplates read it
+	//lock-free.
+	snap atomic.Value
+
+	//buildMu/building implement a minimal single-flight: concurrent
+	//ParseTemplates calls join the build already in progress instead of
+	//each re-walking the filesystem.
+	buildMu  sync.Mutex
+	building *buildCall
+}
+
+type buildCall struct {
+	done chan struct{}
+	errs []error
+}
+
+//templateLayer is one filesystem scanned for templates. name qualifies
+//its templates for cross-layer reference ("name:alias"); prefix is the
+//virtual path its fs.FS-relative paths are joined onto to compute
+//aliases and baseof.html lookups.
+type templateLayer struct {
+	name   string
+	prefix string
+	fsys   fs.FS
 }
 
 func (m *manager) AddDirectories(dirs ...string) (Manager, error) {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	//add incoming directories to list
 	for _, v := range dirs {
 		abs, err := filepath.Abs(v)
 		if err != nil {
 			return m, err
 		}
-		m.dirs[abs] = true
+		slashAbs := filepath.ToSlash(abs)
+		m.layers = append(m.layers, templateLayer{name: slashAbs, prefix: slashAbs, fsys: os.DirFS(abs)})
+	}
+	return m, nil
+}
+
+func (m *manager) AddFS(prefix string, fsys fs.FS) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	m.layers = append(m.layers, templateLayer{name: prefix, prefix: prefix, fsys: fsys})
+	return m
+}
+
+func (m *manager) AddLayer(name, dir string) (Manager, error) {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return m, err
 	}
+	m.layers = append(m.layers, templateLayer{name: name, prefix: filepath.ToSlash(abs), fsys: os.DirFS(abs)})
 	return m, nil
 }
 
 func (m *manager) AddFileExtension(ext string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	m.extensions[ext] = true
 	return m
 }
 
 func (m *manager) RemoveFileExtension(ext string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	delete(m.extensions, ext)
 	return m
 }
 
+func (m *manager) SetTextExtensions(exts ...string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	for _, ext := range exts {
+		m.textExtensions[ext] = true
+	}
+	return m
+}
+
+func (m *manager) SetHTMLExtensions(exts ...string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	for _, ext := range exts {
+		delete(m.textExtensions, ext)
+	}
+	return m
+}
+
+func (m *manager) SetAliasStrategy(fn func(root, path string) []string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	m.aliasStrategy = fn
+	return m
+}
+
 func (m *manager) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
-	if m.reparse {
-		m.ParseTemplates()
+	m.cfgMu.Lock()
+	reparse := m.reparse
+	m.cfgMu.Unlock()
+
+	if reparse {
+		if errs := m.ParseTemplates(); errs != nil {
+			return errs[0]
+		}
+	}
+
+	snap := m.snap.Load().(*snapshot)
+
+	if snap.isText[name] {
+		if snap.textRoot.Lookup(name) == nil {
+			return TemplateNotFoundErr
+		}
+		return snap.textRoot.ExecuteTemplate(wr, name, data)
+	}
+
+	if snap.root.Lookup(name) == nil {
+		return TemplateNotFoundErr
+	}
+
+	if dir, ok := snap.templateDirs[name]; ok {
+		if base, ok := findBaseTemplate(snap.baseTemplates, dir); ok {
+			clone, err := base.Clone()
+			if err != nil {
+				return err
+			}
+
+			for blockName, tree := range snap.childBlocks[name] {
+				if _, err = clone.AddParseTree(blockName, tree); err != nil {
+					return err
+				}
+			}
+
+			return clone.ExecuteTemplate(wr, baseTemplateFileName, data)
+		}
 	}
 
-	m.rootex.Lock()
-	defer m.rootex.Unlock()
+	return snap.root.ExecuteTemplate(wr, name, data)
+}
+
+//findBaseTemplate walks up from dir, through each parent directory, looking
+//for the closest ancestor's baseof.html. This mirrors Hugo's baseof lookup:
+//same directory first, then each parent in turn. The walk does not stop at
+//the root of the layer the template came from; it goes on until the path
+//cannot be shortened any further.
+func findBaseTemplate(baseTemplates map[string]*ht.Template, dir string) (*ht.Template, bool) {
+	for {
+		if base, ok := baseTemplates[dir]; ok {
+			return base, true
+		}
 
-	return m.root.ExecuteTemplate(wr, name, data)
+		parent := vpath.Dir(dir)
+		if parent == dir {
+			return nil, false
+		}
+		dir = parent
+	}
 }
 
 func (m *manager) Delims(left, right string) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	m.leftDelim, m.rightDelim = left, right
 	return m
 }
 
 func (m *manager) Funcs(funcMap ht.FuncMap) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	m.funcMap = funcMap
 	return m
 }
 
 func (m *manager) Lookup(name string) *ht.Template {
-	m.rootex.Lock()
-	defer m.rootex.Unlock()
-	return m.root.Lookup(name)
+	return m.snap.Load().(*snapshot).root.Lookup(name)
+}
+
+func (m *manager) LookupText(name string) *tt.Template {
+	return m.snap.Load().(*snapshot).textRoot.Lookup(name)
 }
 
 func (m *manager) ParseTemplates() []error {
+	m.buildMu.Lock()
+	if call := m.building; call != nil {
+		m.buildMu.Unlock()
+		<-call.done
+		return call.errs
+	}
+
+	call := &buildCall{done: make(chan struct{})}
+	m.building = call
+	m.buildMu.Unlock()
+
+	errs := m.buildSnapshot()
+
+	m.buildMu.Lock()
+	m.building = nil
+	m.buildMu.Unlock()
+
+	call.errs = errs
+	close(call.done)
+
+	return errs
+}
 
-	m.rootex.Lock()
-	if len(m.root.Templates()) > 0 {
-		m.root = ht.New("atomic-template-manager")
-		m.templates = make([]*ht.Template, 0)
+//buildSnapshot walks every added directory, parses everything it finds
+//into a brand new snapshot, and atomically publishes it. It never touches
+//a previously published snapshot, so callers reading the old one via
+//Lookup/ExecuteTemplate/Templates are unaffected until the new one lands.
+func (m *manager) buildSnapshot() []error {
+	m.cfgMu.Lock()
+	layers := make([]templateLayer, len(m.layers))
+	copy(layers, m.layers)
+	extensions := make(map[string]bool, len(m.extensions))
+	for e, v := range m.extensions {
+		extensions[e] = v
+	}
+	textExtensions := make(map[string]bool, len(m.textExtensions))
+	for e, v := range m.textExtensions {
+		textExtensions[e] = v
 	}
-	m.root.Delims(m.leftDelim, m.rightDelim)
-	m.root.Funcs(m.funcMap)
-	m.rootex.Unlock()
-
-	var c = make(chan error)
-	var w sync.WaitGroup
-
-	//the function we'll use for walking each directory
-	var walkDir = func(dir string) {
-		defer w.Done()
-		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err == os.ErrPermission {
-				c <- errors.New(path + " " + err.Error())
+	funcMap := m.funcMap
+	aliasStrategy := m.aliasStrategy
+	left, right := m.leftDelim, m.rightDelim
+	m.cfgMu.Unlock()
+
+	snap := emptySnapshot()
+	snap.root.Delims(left, right)
+	snap.root.Funcs(funcMap)
+	snap.textRoot.Delims(left, right)
+	snap.textRoot.Funcs(tt.FuncMap(funcMap))
+
+	var errs = make([]error, 0)
+
+	//walkLayer scans one layer's filesystem and merges what it finds into
+	//snap. Layers are walked in the order they were added (see below),
+	//never concurrently with each other, so a later layer's AddParseTree
+	//calls deterministically replace an earlier layer's same-named
+	//template - that's what gives higher-priority layers override.
+	var walkLayer = func(l templateLayer) {
+		err := fs.WalkDir(l.fsys, ".", func(relPath string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrPermission) {
+				errs = append(errs, errors.New(vpath.Join(l.prefix, relPath)+" "+err.Error()))
 				return nil
 			}
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			virtualPath := l.prefix
+			if relPath != "." {
+				virtualPath = vpath.Join(l.prefix, relPath)
+			}
+
+			if d.Name() == baseTemplateFileName {
+				fileContents, err := fs.ReadFile(l.fsys, relPath)
+				if err != nil {
+					return err
+				}
+
+				base := ht.New(baseTemplateFileName)
+				base.Delims(left, right)
+				base.Funcs(funcMap)
+
+				if _, err = base.Parse(string(fileContents)); err != nil {
+					return err
+				}
 
-			if info.IsDir() {
+				snap.baseTemplates[vpath.Dir(virtualPath)] = base
 				return nil
 			}
 
 			var ext string
-			ext = strings.TrimPrefix(filepath.Ext(info.Name()), ".")
+			ext = strings.TrimPrefix(vpath.Ext(d.Name()), ".")
 
 			//if the file extension matches any file extension
 			//we're looking for then parse it and add it
-			if _, ok := m.extensions[ext]; ok {
-				alias := templateAliases(dir, path, ext)
-				lalias := len(alias)
-				if lalias == 0 {
+			if _, ok := extensions[ext]; ok {
+				var base []string
+				if aliasStrategy != nil {
+					base = aliasStrategy(l.prefix, virtualPath)
+				} else {
+					base = templateAliases(l.prefix, virtualPath, ext)
+				}
+				if len(base) == 0 {
 					return nil
 				}
-				var newTemplate *ht.Template
+
+				//every alias is reachable unqualified (last layer wins on
+				//a collision, since layers are walked in order) and also
+				//under "name:alias" so a lower layer stays reachable even
+				//once a higher layer has shadowed it.
+				alias := make([]string, 0, len(base)*2)
+				alias = append(alias, base...)
+				for _, a := range base {
+					alias = append(alias, l.name+":"+a)
+				}
+
+				if textExtensions[ext] {
+					fileContents, err := fs.ReadFile(l.fsys, relPath)
+					if err != nil {
+						return err
+					}
+
+					newTextTemplate := snap.textRoot.New(alias[0])
+					if _, err = newTextTemplate.Parse(string(fileContents)); err != nil {
+						return err
+					}
+
+					snap.textTemplates = append(snap.textTemplates, newTextTemplate)
+					for _, a := range alias {
+						snap.isText[a] = true
+					}
+					for i := 1; i < len(alias); i++ {
+						if _, err = snap.textRoot.AddParseTree(alias[i], newTextTemplate.Tree); err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+
 				//create the new template using the first alias
-				m.rootex.Lock()
-				newTemplate = m.root.New(alias[0])
-				fileContents, err := ioutil.ReadFile(path)
+				newTemplate := snap.root.New(alias[0])
+				fileContents, err := fs.ReadFile(l.fsys, relPath)
 
 				if err != nil {
 					return err
@@ -184,78 +542,99 @@ func (m *manager) ParseTemplates() []error {
 					return err
 				}
 
-				m.templates = append(m.templates, newTemplate)
+				snap.templates = append(snap.templates, newTemplate)
+
+				for _, a := range alias {
+					snap.templateDirs[a] = vpath.Dir(virtualPath)
+				}
+
+				//parse the file again in isolation so we know exactly which
+				//blocks this file (and only this file) defines. Templates
+				//are associated by name across all of snap.root, so
+				//without this a later sibling page defining the same
+				//block name (e.g. "main") would clobber this one by the
+				//time ExecuteTemplate runs.
+				standalone := ht.New(alias[0])
+				standalone.Delims(left, right)
+				standalone.Funcs(funcMap)
+				if _, err = standalone.Parse(string(fileContents)); err != nil {
+					return err
+				}
+				blocks := make(map[string]*parse.Tree)
+				for _, t := range standalone.Templates() {
+					blocks[t.Name()] = t.Tree
+				}
+				for _, a := range alias {
+					snap.childBlocks[a] = blocks
+				}
 
 				for i := 1; i < len(alias); i++ {
-					_, err = m.root.AddParseTree(alias[i], newTemplate.Tree)
+					_, err = snap.root.AddParseTree(alias[i], newTemplate.Tree)
 					if err != nil {
 						return err
 					}
 				}
-				m.rootex.Unlock()
-
 			}
 
 			return nil
 		})
 
 		if err != nil {
-			c <- err
+			errs = append(errs, err)
 		}
 	}
 
-	//start parsing the directories
-	for d, _ := range m.dirs {
-		w.Add(1)
-		go walkDir(d)
+	for _, l := range layers {
+		walkLayer(l)
 	}
 
-	go func() {
-		w.Wait()
-		close(c)
-	}()
-
-	var errors = make([]error, 0)
-	for err := range c {
-		errors = append(errors, err)
-	}
+	m.snap.Store(snap)
 
-	if len(errors) > 0 {
-		return errors
+	if len(errs) > 0 {
+		return errs
 	}
 
 	return nil
 }
 
 func (m *manager) SetReparseOnExecute(reparse bool) Manager {
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
 	m.reparse = reparse
 	return m
 }
 
 func (m *manager) Templates() []*ht.Template {
-	return m.templates
+	return m.snap.Load().(*snapshot).templates
+}
+
+func (m *manager) TextTemplates() []*tt.Template {
+	return m.snap.Load().(*snapshot).textTemplates
 }
 
 //templateAliases will generate the aliases that
 //we will be able to use to include/access the
-//template located by path.
+//template located by path. path is the virtual, forward-slash-separated
+//path computed by buildSnapshot (root joined with the fs.FS-relative
+//path), so aliases come out identical whether root is a real directory
+//added via AddDirectories or an fs.FS added via AddFS.
 //Root should be the root template directory
 //so we can generate the aliases accordingly.
 //
 //Ex. Root = /tmp
 //    Path = /tmp/atom/template-1.html
-//    Aliases = { "atom-template-1", "atom/template-1" }
+//    Aliases = { "atom/template-1.html", "atom-template-1" }
 //Ex. Root = /tmp
 //    Path = /tmp/atom/subdir/template-1.html
-//    Aliases = { "atom-template-1", "atom/subdir/template-1" }
+//    Aliases = { "atom/subdir/template-1.html", "atom-subdir-template-1" }
 //Ex. Root = /tmp
 //    Path = /tmp/00-atom/00-subdir/template-1.html
-//    Aliases = { "atom-template-1", "00-atom/00-subdir/template-1" }
+//    Aliases = { "00-atom/00-subdir/template-1.html", "atom-subdir-template-1" }
 func templateAliases(root, path, ext string) []string {
 	alias := make([]string, 0, 2)
 	aliasWithExtension := strings.TrimPrefix(path, root+"/")
 	aliasWithoutExtension := strings.TrimSuffix(aliasWithExtension, "."+ext)
-	parts := strings.Split(aliasWithoutExtension, string(os.PathSeparator))
+	parts := strings.Split(aliasWithoutExtension, "/")
 
 	if len(parts) < 1 {
 		panic("Root and path are the same ( root = " + root + ", path = " + path + " )")
@@ -266,24 +645,41 @@ func templateAliases(root, path, ext string) []string {
 	if len(parts) == 1 {
 		alias = append(alias, removeLeadingNumbers(parts[0]))
 	} else {
-		alias = append(alias, removeLeadingNumbers(parts[0])+"-"+removeLeadingNumbers(parts[len(parts)-1]))
+		shortParts := make([]string, len(parts))
+		for i, p := range parts {
+			shortParts[i] = removeLeadingNumbers(p)
+		}
+		alias = append(alias, strings.Join(shortParts, "-"))
 	}
 	return alias
 }
 
+//removeLeadingNumbers strips a leading run of digits, plus one trailing
+//"-" or "_" if present, from a single path segment. This is what lets
+//Pattern Lab-style numeric ordering prefixes (e.g. "00-atoms",
+//"10_pages") stay on disk for sort order without polluting the
+//shorthand template alias.
 func removeLeadingNumbers(p string) string {
-	return p
+	i := 0
+	for i < len(p) && p[i] >= '0' && p[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return p
+	}
+	if i < len(p) && (p[i] == '-' || p[i] == '_') {
+		i++
+	}
+	return p[i:]
 }
 
 func New() Manager {
 	man := new(manager)
-	man.root = ht.New("atomic-template-manager")
-	man.dirs = make(map[string]bool)
 	man.extensions = make(map[string]bool)
 	man.extensions["html"] = true
 	man.extensions["tpl"] = true
+	man.textExtensions = make(map[string]bool)
 	man.reparse = false
-	man.templates = make([]*ht.Template, 0)
-	man.rootex = new(sync.Mutex)
+	man.snap.Store(emptySnapshot())
 	return man
 }
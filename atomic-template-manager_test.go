@@ -1,8 +1,12 @@
 package atm
 
 import (
+	"bytes"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 )
 
 //create template directory and sub directories
@@ -50,7 +54,7 @@ func createTestTemplates(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = writeTemplateFile(dir+"/templates/pages/page-1.html", `page 1 {{template "atoms-font-1"}}`)
+	err = writeTemplateFile(dir+"/templates/pages/page-1.html", `page 1 {{template "atoms-fonts-font-1"}}`)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -129,6 +133,201 @@ func TestDefaultTemplatesAreFound(t *testing.T) {
 	}
 }
 
+func TestTextExtensionIsNotHTMLEscaped(t *testing.T) {
+	createDirs(t)
+	defer destroyAll(t)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeTemplateFile(dir+"/templates/feed.xml", `<link>{{.}}</link>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var man Manager = New()
+	man.AddDirectories("./templates")
+	man.AddFileExtension("xml")
+	man.SetTextExtensions("xml")
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	if len(man.TextTemplates()) != 1 {
+		t.Fatalf("We expected 1 text template but had : %d, %v", len(man.TextTemplates()), man.TextTemplates())
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "feed", "a&b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "<link>a&b</link>" {
+		t.Fatalf("expected unescaped text output, got : %q", buf.String())
+	}
+}
+
+func TestBaseTemplateIsApplied(t *testing.T) {
+	createDirs(t)
+	defer destroyAll(t)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeTemplateFile(dir+"/templates/pages/baseof.html", `<title>{{template "title" .}}</title><body>{{template "main" .}}</body>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeTemplateFile(dir+"/templates/pages/front-page/index.html",
+		`{{define "title"}}Home{{end}}{{define "main"}}Hello{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var man Manager = New()
+	man.AddDirectories("./templates")
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "pages-front-page-index", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "<title>Home</title><body>Hello</body>" {
+		t.Fatalf("expected base template to wrap child blocks, got : %q", buf.String())
+	}
+}
+
+func TestAddFSFindsTemplatesWithoutTouchingDisk(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pages/page-1.html": &fstest.MapFile{Data: []byte("page 1")},
+		"atoms/atom-1.html":  &fstest.MapFile{Data: []byte("atom 1")},
+	}
+
+	var man Manager = New()
+	man.AddFS("mem", fsys)
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	if len(man.Templates()) != 2 {
+		t.Fatalf("We expected 2 templates but had : %d, %v", len(man.Templates()), man.Templates())
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "pages-page-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "page 1" {
+		t.Fatalf("expected \"page 1\", got : %q", buf.String())
+	}
+}
+
+func TestLaterLayerOverridesEarlierLayerButStaysReachableQualified(t *testing.T) {
+	base := fstest.MapFS{
+		"pages/page-1.html": &fstest.MapFile{Data: []byte("base page 1")},
+	}
+	override := fstest.MapFS{
+		"pages/page-1.html": &fstest.MapFile{Data: []byte("override page 1")},
+	}
+
+	var man Manager = New()
+	man.AddFS("base", base)
+	man.AddFS("override", override)
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "pages-page-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "override page 1" {
+		t.Fatalf("expected the later layer to win, got : %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := man.ExecuteTemplate(&buf, "base:pages-page-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "base page 1" {
+		t.Fatalf("expected the shadowed layer to stay reachable by qualified name, got : %q", buf.String())
+	}
+}
+
+func TestConcurrentExecuteTemplateDoesNotRace(t *testing.T) {
+	createDirs(t)
+	createTestTemplates(t)
+	defer destroyAll(t)
+
+	var man Manager = New()
+	man.AddDirectories("./templates")
+	man.SetReparseOnExecute(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf bytes.Buffer
+			if err := man.ExecuteTemplate(&buf, "top-level", nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLeadingNumbersAreStrippedFromEveryAliasSegment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"00-atoms/01-fonts/02-font-1.html": &fstest.MapFile{Data: []byte("font 1")},
+	}
+
+	var man Manager = New()
+	man.AddFS("mem", fsys)
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "atoms-fonts-font-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "font 1" {
+		t.Fatalf("expected \"font 1\", got : %q", buf.String())
+	}
+}
+
+func TestSetAliasStrategyOverridesDefaultNaming(t *testing.T) {
+	fsys := fstest.MapFS{
+		"atoms/font-1.html": &fstest.MapFile{Data: []byte("font 1")},
+	}
+
+	var man Manager = New()
+	man.AddFS("mem", fsys)
+	man.SetAliasStrategy(func(root, path string) []string {
+		return []string{strings.TrimPrefix(path, root+"/")}
+	})
+	if errs := man.ParseTemplates(); errs != nil {
+		t.Fatal(errs)
+	}
+
+	var buf bytes.Buffer
+	if err := man.ExecuteTemplate(&buf, "atoms/font-1.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "font 1" {
+		t.Fatalf("expected \"font 1\", got : %q", buf.String())
+	}
+}
+
 func TestRemoveExtensionAndAddExtensionWork(t *testing.T) {
 	createDirs(t)
 	createTestTemplates(t)
@@ -50,9 +50,9 @@ Now you can do:
 		//we can also use the full path to call the same template
 		man.ExecuteTemplate( os.Stdout, "pages/page-1.html", nil )
 
-		//Notice in the call below that the subatoms subdirectory is omitted from
-		//the template name
-		man.ExecuteTemplate( os.Stdout, "atoms-sub-atom-1", nil )
+		//Notice that every path segment, including the subatoms subdirectory,
+		//becomes part of the hyphenated alias
+		man.ExecuteTemplate( os.Stdout, "atoms-subatoms-sub-atom-1", nil )
 
 		//we can also just use the long name
 		man.ExecuteTemplate( os.Stdout, "atoms/subatoms/sub-atom-1.html", nil )
@@ -91,5 +91,116 @@ Now you can do:
 		//reparse all templates on ExecuteTemplate, ok for dev not for prod
 		man.SetReparseOnExecute(true)
 	}
+
+Base templates / layout inheritance
+
+If a directory contains a baseof.html, every template in that directory
+(and any sub-directory that doesn't have its own baseof.html) is wrapped
+in it when executed:
+
+    /tmp/template-dir/
+    -----------------/baseof.html
+    -----------------/pages/
+    -----------------------/page-1.html
+
+baseof.html might look like:
+
+    <html>
+    <head><title>{{template "title" .}}</title></head>
+    <body>{{template "main" .}}</body>
+    </html>
+
+and pages/page-1.html supplies the blocks baseof.html asks for:
+
+    {{define "title"}}Home{{end}}
+    {{define "main"}}Hello, world!{{end}}
+
+man.ExecuteTemplate(os.Stdout, "pages-page-1", nil) renders page-1's blocks
+inside baseof.html rather than page-1.html's own (empty) content.
+
+Text templates
+
+By default every managed extension is parsed with html/template, which
+HTML-escapes interpolated values. Mark an extension as text instead to
+parse it with text/template, e.g. for an RSS feed, sitemap, or plain-text
+email living alongside your HTML pages:
+
+	man.AddFileExtension("xml")
+	man.SetTextExtensions("xml")
+
+	man.ExecuteTemplate(os.Stdout, "feed-rss", data)
+
+Lookup, LookupText, Templates, and TextTemplates split the same way:
+html-mode templates show up under Lookup/Templates, text-mode ones under
+LookupText/TextTemplates.
+
+Filesystem sources
+
+AddDirectories always reads from disk. To ship templates inside a single
+binary, or to drive tests without touching disk, use AddFS with any
+io/fs.FS:
+
+	//go:embed templates
+	var templateFS embed.FS
+
+	man.AddFS("templates", templateFS)
+
+Aliases are computed the same way regardless of whether a root came from
+AddDirectories or AddFS.
+
+Layered overrides
+
+AddFS and AddDirectories each add their templates as their own named layer,
+named after the prefix/directory passed in. Layers are checked in the order
+they were added, so a later layer's template wins when it shares a short
+alias with an earlier one - handy for a theme/site split where the site
+overrides individual theme templates:
+
+	man.AddFS("theme", themeFS)
+	man.AddFS("site", siteFS)
+
+If both filesystems have a pages/page-1.html, ExecuteTemplate(w,
+"pages-page-1", data) renders site's. theme's is still reachable as
+"theme:pages-page-1", so an overriding template can wrap or fall back to
+the one it's replacing. AddLayer is the same as AddFS/AddDirectories but
+lets you give a directory an explicit layer name instead of using its path:
+
+	man.AddLayer("theme", "/usr/share/myapp/theme")
+	man.AddLayer("site", "./templates")
+
+Alias naming
+
+The shorthand alias joins every directory segment between root and the
+file with a hyphen, and strips any leading Pattern Lab-style ordering
+prefix (digits plus an optional "-" or "_") from each segment first, so
+it stays out of the alias while still controlling sort order on disk:
+
+    /tmp/template-dir/
+    -----------------/00-atoms/
+    ---------------------------/01-fonts/
+    -----------------------------------/02-font-1.html
+
+man.ExecuteTemplate(os.Stdout, "atoms-fonts-font-1", nil) reaches that
+file; so does the unshortened "00-atoms/01-fonts/02-font-1.html".
+
+If this naming scheme doesn't fit - you want numeric prefixes preserved,
+a different separator, or a flat basename-only scheme - call
+SetAliasStrategy before ParseTemplates with your own function:
+
+	man.SetAliasStrategy(func(root, path string) []string {
+		return []string{strings.TrimPrefix(path, root+"/")}
+	})
+
+Concurrency
+
+Lookup, LookupText, Templates, and TextTemplates take no lock, and
+ExecuteTemplate takes only a brief configuration lock to read the reparse
+setting - ParseTemplates builds the next template tree off to the side and
+publishes it atomically once it's ready, so it's safe to call
+ExecuteTemplate from many goroutines at once, including with
+SetReparseOnExecute(true) under concurrent HTTP handlers. With
+SetReparseOnExecute(true), each ExecuteTemplate call first runs
+ParseTemplates, or joins the one already in progress, and waits for it to
+finish before executing.
 */
 package atm